@@ -0,0 +1,98 @@
+// Package auth replaces the static Security-Code header with a pluggable
+// chain of authentication modes: the original legacy code, HMAC-signed
+// requests, and Google OAuth ID tokens.
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Identity is what a successful Authenticate call establishes about the
+// caller. GoogleID is only populated for OAuth-authenticated requests and
+// should be used to scope results to that user's own sessions.
+type Identity struct {
+	GoogleID string
+}
+
+// Mode is one authentication method an operator can enable via AUTH_MODE.
+type Mode string
+
+const (
+	ModeLegacy Mode = "legacy"
+	ModeHMAC   Mode = "hmac"
+	ModeOAuth  Mode = "oauth"
+)
+
+// Authenticator tries each enabled Mode in turn and succeeds as soon as one
+// of them accepts the request.
+type Authenticator struct {
+	modes      map[Mode]bool
+	legacyCode string
+	hmac       *HMACVerifier
+	oauth      *OAuthVerifier
+}
+
+// ParseModes splits the AUTH_MODE env value ("legacy", "hmac,oauth", ...)
+// into a set of Modes. An empty spec defaults to legacy-only, so existing
+// deployments keep working unchanged.
+func ParseModes(spec string) map[Mode]bool {
+	modes := make(map[Mode]bool)
+	if strings.TrimSpace(spec) == "" {
+		modes[ModeLegacy] = true
+		return modes
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			modes[Mode(part)] = true
+		}
+	}
+	return modes
+}
+
+// NewAuthenticator builds an Authenticator for the given modes. hmac and/or
+// oauth may be nil if that mode isn't configured, even if enabled; such a
+// mode will simply always fail.
+func NewAuthenticator(modes map[Mode]bool, legacyCode string, hmac *HMACVerifier, oauth *OAuthVerifier) *Authenticator {
+	return &Authenticator{modes: modes, legacyCode: legacyCode, hmac: hmac, oauth: oauth}
+}
+
+// Authenticate checks the request against every enabled mode and returns
+// the Identity of the first one that accepts it.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	if a.modes[ModeLegacy] {
+		if code := r.Header.Get("Security-Code"); code != "" && code == a.legacyCode {
+			return Identity{}, nil
+		}
+	}
+
+	if a.modes[ModeHMAC] && a.hmac != nil {
+		if r.Header.Get("Authorization") != "" {
+			if err := a.hmac.Verify(r); err == nil {
+				return Identity{}, nil
+			}
+		}
+	}
+
+	if a.modes[ModeOAuth] && a.oauth != nil {
+		if token := bearerToken(r); token != "" {
+			googleID, err := a.oauth.Verify(r.Context(), token)
+			if err == nil {
+				return Identity{GoogleID: googleID}, nil
+			}
+		}
+	}
+
+	return Identity{}, fmt.Errorf("unauthorized")
+}
+
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if strings.HasPrefix(auth, prefix) {
+		return strings.TrimPrefix(auth, prefix)
+	}
+	return ""
+}
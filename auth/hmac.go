@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxClockSkew bounds how far a request's ts may drift from the server's
+// clock before it's rejected. A (key, sig) pair is kept in the replay cache
+// for twice that long, since an accepted ts may lie up to maxClockSkew in
+// the future.
+const maxClockSkew = 5 * time.Minute
+
+// HMACVerifier checks requests signed as:
+//
+//	Authorization: HMAC key=<id>, ts=<unix>, sig=<hex>
+//
+// where sig is HMAC-SHA256, keyed by the secret for <id>, over
+// "<method>\n<path>\n<body>\n<ts>".
+type HMACVerifier struct {
+	secrets map[string]string // key id -> secret
+
+	mu   sync.Mutex
+	seen map[string]time.Time // "key:sig" -> when first seen, for replay detection
+}
+
+// NewHMACVerifier builds a verifier for the given key id -> secret map.
+func NewHMACVerifier(secrets map[string]string) *HMACVerifier {
+	return &HMACVerifier{secrets: secrets, seen: make(map[string]time.Time)}
+}
+
+// ParseHMACSecrets parses the HMAC_KEYS env value, "id1:secret1,id2:secret2".
+func ParseHMACSecrets(spec string) map[string]string {
+	secrets := make(map[string]string)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) == 2 {
+			secrets[kv[0]] = kv[1]
+		}
+	}
+	return secrets
+}
+
+// Verify checks the request's Authorization header against its method,
+// path, and body. It consumes and restores r.Body so later handlers can
+// still read it.
+func (v *HMACVerifier) Verify(r *http.Request) error {
+	keyID, ts, sig, err := parseHMACHeader(r.Header.Get("Authorization"))
+	if err != nil {
+		return err
+	}
+
+	secret, ok := v.secrets[keyID]
+	if !ok {
+		return fmt.Errorf("unknown HMAC key id %q", keyID)
+	}
+
+	requestTime := time.Unix(ts, 0)
+	if skew := time.Since(requestTime); skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("request timestamp outside the %s clock skew window", maxClockSkew)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	expected := sign(secret, r.Method, r.URL.Path, body, ts)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return fmt.Errorf("invalid HMAC signature")
+	}
+
+	if !v.markSeen(keyID, sig) {
+		return fmt.Errorf("replayed HMAC signature")
+	}
+
+	return nil
+}
+
+func sign(secret, method, path string, body []byte, ts int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", method, path, body, ts)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func parseHMACHeader(header string) (keyID string, ts int64, sig string, err error) {
+	const prefix = "HMAC "
+	if !strings.HasPrefix(header, prefix) {
+		return "", 0, "", fmt.Errorf("missing HMAC Authorization header")
+	}
+
+	fields := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(header, prefix), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+	}
+
+	keyID, ok := fields["key"]
+	if !ok {
+		return "", 0, "", fmt.Errorf("missing key= in HMAC header")
+	}
+	tsStr, ok := fields["ts"]
+	if !ok {
+		return "", 0, "", fmt.Errorf("missing ts= in HMAC header")
+	}
+	sig, ok = fields["sig"]
+	if !ok {
+		return "", 0, "", fmt.Errorf("missing sig= in HMAC header")
+	}
+
+	ts, err = strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("invalid ts= in HMAC header: %w", err)
+	}
+
+	return keyID, ts, sig, nil
+}
+
+// markSeen records (keyID, sig) as used and reports whether this is the
+// first time it's been seen, rejecting replays within the clock skew
+// window. It also opportunistically evicts entries old enough that a
+// replay would already have failed the timestamp check.
+func (v *HMACVerifier) markSeen(keyID, sig string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	for k, seenAt := range v.seen {
+		if now.Sub(seenAt) > 2*maxClockSkew {
+			delete(v.seen, k)
+		}
+	}
+
+	k := keyID + ":" + sig
+	if _, replayed := v.seen[k]; replayed {
+		return false
+	}
+	v.seen[k] = now
+	return true
+}
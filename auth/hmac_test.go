@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedRequest(t *testing.T, secret, method, path string, body string, ts int64) *http.Request {
+	t.Helper()
+	sig := sign(secret, method, path, []byte(body), ts)
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Authorization", "HMAC key=k1, ts="+strconv.FormatInt(ts, 10)+", sig="+sig)
+	return r
+}
+
+func TestHMACVerifierAcceptsValidSignature(t *testing.T) {
+	v := NewHMACVerifier(map[string]string{"k1": "secret"})
+	r := signedRequest(t, "secret", http.MethodGet, "/sessions", "", time.Now().Unix())
+	if err := v.Verify(r); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestHMACVerifierRejectsReplay(t *testing.T) {
+	v := NewHMACVerifier(map[string]string{"k1": "secret"})
+	r1 := signedRequest(t, "secret", http.MethodGet, "/sessions", "", time.Now().Unix())
+	if err := v.Verify(r1); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+
+	r2 := signedRequest(t, "secret", http.MethodGet, "/sessions", "", time.Now().Unix())
+	r2.Header.Set("Authorization", r1.Header.Get("Authorization"))
+	if err := v.Verify(r2); err == nil {
+		t.Fatal("expected replayed signature to be rejected")
+	}
+}
+
+func TestHMACVerifierRejectsStaleTimestamp(t *testing.T) {
+	v := NewHMACVerifier(map[string]string{"k1": "secret"})
+	r := signedRequest(t, "secret", http.MethodGet, "/sessions", "", time.Now().Add(-10*time.Minute).Unix())
+	if err := v.Verify(r); err == nil {
+		t.Fatal("expected a timestamp outside the clock skew window to be rejected")
+	}
+}
+
+func TestHMACVerifierRejectsBadSignature(t *testing.T) {
+	v := NewHMACVerifier(map[string]string{"k1": "secret"})
+	r := signedRequest(t, "wrong-secret", http.MethodGet, "/sessions", "", time.Now().Unix())
+	if err := v.Verify(r); err == nil {
+		t.Fatal("expected an invalid signature to be rejected")
+	}
+}
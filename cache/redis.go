@@ -0,0 +1,62 @@
+// Package cache provides a Redis-backed response cache for the daily
+// SessionTime totals, so a dashboard polling the API doesn't make it
+// rescan and recompute the same day's activity on every request.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/thanhlong1203/timesheet_opentalk/history"
+)
+
+// SessionTimeCache caches the []history.SessionTime result for a
+// (date, clanID) pair, keyed so a miss for one clan doesn't evict another's
+// entry.
+type SessionTimeCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewSessionTimeCache connects to the Redis instance at addr. ttl controls
+// how long an entry is served before the next request recomputes it.
+func NewSessionTimeCache(addr string, ttl time.Duration) *SessionTimeCache {
+	return &SessionTimeCache{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+		ttl:    ttl,
+	}
+}
+
+func key(date time.Time, clanID string) string {
+	return fmt.Sprintf("opentalk:sessiontime:%s:%s", date.UTC().Format("2006-01-02"), clanID)
+}
+
+// Get returns the cached totals for (date, clanID), or ok=false on a cache
+// miss.
+func (c *SessionTimeCache) Get(ctx context.Context, date time.Time, clanID string) (totals []history.SessionTime, ok bool, err error) {
+	raw, err := c.client.Get(ctx, key(date, clanID)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := json.Unmarshal(raw, &totals); err != nil {
+		return nil, false, err
+	}
+	return totals, true, nil
+}
+
+// Set stores totals for (date, clanID) with the cache's configured TTL.
+func (c *SessionTimeCache) Set(ctx context.Context, date time.Time, clanID string, totals []history.SessionTime) error {
+	raw, err := json.Marshal(totals)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key(date, clanID), raw, c.ttl).Err()
+}
@@ -0,0 +1,119 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// CachingStore answers Between by serving already-elapsed days from a fast
+// snapshot store, falling back to a slower primary store (and backfilling
+// the snapshot) on a miss. Today's sessions are never served from the
+// snapshot, since they can still change before the day closes out.
+type CachingStore struct {
+	primary  Store
+	snapshot Store
+}
+
+// NewCachingStore builds a CachingStore that checks snapshot before falling
+// back to primary for any day that has fully elapsed, backfilling snapshot
+// with whatever primary returns so the next query for that day is served
+// from snapshot alone.
+func NewCachingStore(primary, snapshot Store) *CachingStore {
+	return &CachingStore{primary: primary, snapshot: snapshot}
+}
+
+// Append records session in the snapshot store. The primary store learns
+// about sessions on its own (e.g. by rescanning raw activity rows), so it
+// isn't written to here.
+func (c *CachingStore) Append(session Session) error {
+	return c.snapshot.Append(session)
+}
+
+// Between returns sessions in [start, end), serving each fully-elapsed day
+// from the snapshot store (backfilling it from primary on a miss) and
+// always querying primary directly for the day in progress.
+func (c *CachingStore) Between(start, end time.Time, filter Filter, limit int, before time.Time) ([]Session, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	var sessions []Session
+	for day := start.UTC().Truncate(24 * time.Hour); day.Before(end); day = day.Add(24 * time.Hour) {
+		dayStart, dayEnd := day, day.Add(24*time.Hour)
+		if dayStart.Before(start) {
+			dayStart = start
+		}
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+
+		if !day.Before(today) {
+			// Still in progress: always live, never cached.
+			fresh, err := c.primary.Between(dayStart, dayEnd, filter, 0, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			sessions = append(sessions, fresh...)
+			continue
+		}
+
+		dayBoundary := day.Add(24 * time.Hour)
+		daySessions, err := c.snapshot.Between(day, dayBoundary, Filter{}, 0, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		if len(daySessions) == 0 {
+			daySessions, err = c.primary.Between(day, dayBoundary, Filter{}, 0, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range daySessions {
+				if err := c.snapshot.Append(s); err != nil {
+					return nil, err
+				}
+			}
+		}
+		var inRange []Session
+		for _, s := range daySessions {
+			if s.StartTime.Before(dayStart) || !s.StartTime.Before(dayEnd) {
+				continue
+			}
+			inRange = append(inRange, s)
+		}
+		sessions = append(sessions, applyFilter(inRange, filter)...)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	if !before.IsZero() {
+		trimmed := sessions[:0]
+		for _, s := range sessions {
+			if s.StartTime.Before(before) {
+				trimmed = append(trimmed, s)
+			}
+		}
+		sessions = trimmed
+	}
+
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[len(sessions)-limit:]
+	}
+
+	return sessions, nil
+}
+
+// Latest returns the n most recent sessions for user, looking back 90 days.
+func (c *CachingStore) Latest(user string, n int) ([]Session, error) {
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -90)
+	sessions, err := c.Between(start, end, Filter{User: user}, n, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	reversed := make([]Session, len(sessions))
+	for i, sess := range sessions {
+		reversed[len(sessions)-1-i] = sess
+	}
+	return reversed, nil
+}
@@ -0,0 +1,65 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory Store used to drive CachingStore in tests
+// without a real Postgres/JSONL backend.
+type fakeStore struct {
+	sessions []Session
+}
+
+func (f *fakeStore) Append(session Session) error {
+	f.sessions = append(f.sessions, session)
+	return nil
+}
+
+func (f *fakeStore) Between(start, end time.Time, filter Filter, limit int, before time.Time) ([]Session, error) {
+	var out []Session
+	for _, s := range f.sessions {
+		if s.StartTime.Before(start) || !s.StartTime.Before(end) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return applyFilter(out, filter), nil
+}
+
+func (f *fakeStore) Latest(user string, n int) ([]Session, error) {
+	return nil, nil
+}
+
+// TestCachingStoreBackfillsWholeDayNotJustTheQueriedRange guards against a
+// partial-range query (e.g. start=00:00, end=12:00) permanently truncating
+// an elapsed day's snapshot: once a day has anything cached, later full-day
+// queries must not be starved of the sessions outside the first query's
+// clipped window.
+func TestCachingStoreBackfillsWholeDayNotJustTheQueriedRange(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	primary := &fakeStore{sessions: []Session{
+		{Name: "a", GoogleID: "a", StartTime: day.Add(1 * time.Hour), EndTime: day.Add(2 * time.Hour)},
+		{Name: "b", GoogleID: "b", StartTime: day.Add(18 * time.Hour), EndTime: day.Add(19 * time.Hour)},
+	}}
+	snapshot := &fakeStore{}
+	c := NewCachingStore(primary, snapshot)
+
+	// A partial-day query (only the morning) should not poison the
+	// snapshot for the rest of the day.
+	partial, err := c.Between(day, day.Add(12*time.Hour), Filter{}, 0, time.Time{})
+	if err != nil {
+		t.Fatalf("partial query: %v", err)
+	}
+	if len(partial) != 1 {
+		t.Fatalf("partial query returned %d sessions, want 1", len(partial))
+	}
+
+	full, err := c.Between(day, day.Add(24*time.Hour), Filter{}, 0, time.Time{})
+	if err != nil {
+		t.Fatalf("full query: %v", err)
+	}
+	if len(full) != 2 {
+		t.Fatalf("full-day query after partial backfill returned %d sessions, want 2", len(full))
+	}
+}
@@ -0,0 +1,65 @@
+package history
+
+import "time"
+
+// CalculateTotalTimeForDate scores each session against every window and
+// returns, per user, the per-window totals plus a grand total. A session
+// only contributes to a window if the window applies to that weekday and
+// the overlap is at least window.MinDuration.
+func CalculateTotalTimeForDate(sessions []Session, date time.Time, windows []Window) map[string]SessionTime {
+	totalTimeMap := make(map[string]SessionTime)
+	startOfDay := date.Truncate(24 * time.Hour)
+
+	identities := make(map[string]Session, len(sessions))
+	for _, s := range sessions {
+		identities[s.Name+s.GoogleID] = s
+	}
+
+	for _, w := range windows {
+		windowStart, windowEnd := w.Absolute(date)
+		if !w.appliesToWeekday(windowStart.Weekday()) {
+			continue
+		}
+
+		perUser := make(map[string]time.Duration)
+		for _, s := range sessions {
+			effectiveStart := s.StartTime
+			effectiveEnd := s.EndTime
+
+			if effectiveStart.Before(windowStart) {
+				effectiveStart = windowStart
+			}
+			if effectiveEnd.After(windowEnd) {
+				effectiveEnd = windowEnd
+			}
+			if !effectiveStart.Before(effectiveEnd) {
+				continue
+			}
+
+			duration := effectiveEnd.Sub(effectiveStart)
+			if duration < w.MinDuration {
+				continue
+			}
+
+			userKey := s.Name + s.GoogleID
+			perUser[userKey] += duration
+		}
+
+		for userKey, duration := range perUser {
+			sessionTime, exists := totalTimeMap[userKey]
+			if !exists {
+				identity := identities[userKey]
+				sessionTime = SessionTime{
+					Name:     identity.Name,
+					GoogleID: identity.GoogleID,
+					Date:     startOfDay,
+				}
+			}
+			sessionTime.TotalTime += duration
+			sessionTime.Windows = append(sessionTime.Windows, WindowTotal{Label: w.Label, TotalTime: duration})
+			totalTimeMap[userKey] = sessionTime
+		}
+	}
+
+	return totalTimeMap
+}
@@ -0,0 +1,78 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateTotalTimeForDateMultiWindow(t *testing.T) {
+	date := time.Date(2026, 7, 27, 0, 0, 0, 0, time.UTC) // a Monday
+	windows := []Window{
+		{Label: "morning", Start: 9 * time.Hour, End: 10 * time.Hour, Timezone: time.UTC},
+		{Label: "afternoon", Start: 14 * time.Hour, End: 15 * time.Hour, Timezone: time.UTC},
+	}
+	sessions := []Session{
+		{
+			Name: "Ada", GoogleID: "ada",
+			StartTime: date.Add(9 * time.Hour),
+			EndTime:   date.Add(9*time.Hour + 30*time.Minute),
+		},
+		{
+			Name: "Ada", GoogleID: "ada",
+			StartTime: date.Add(14 * time.Hour),
+			EndTime:   date.Add(14*time.Hour + 20*time.Minute),
+		},
+	}
+
+	totals := CalculateTotalTimeForDate(sessions, date, windows)
+	got, ok := totals["Adaada"]
+	if !ok {
+		t.Fatalf("no total for Ada: %+v", totals)
+	}
+	if want := 50 * time.Minute; got.TotalTime != want {
+		t.Fatalf("TotalTime = %v, want %v", got.TotalTime, want)
+	}
+	if len(got.Windows) != 2 {
+		t.Fatalf("Windows = %+v, want 2 entries", got.Windows)
+	}
+}
+
+func TestCalculateTotalTimeForDateMinDurationExcludesShortOverlap(t *testing.T) {
+	date := time.Date(2026, 7, 27, 0, 0, 0, 0, time.UTC)
+	windows := []Window{
+		{Label: "opentalk", Start: 9 * time.Hour, End: 10 * time.Hour, Timezone: time.UTC, MinDuration: 15 * time.Minute},
+	}
+	sessions := []Session{
+		{
+			Name: "Bob", GoogleID: "bob",
+			StartTime: date.Add(9*time.Hour + 50*time.Minute),
+			EndTime:   date.Add(10 * time.Hour), // only 10m overlap, under MinDuration
+		},
+	}
+
+	totals := CalculateTotalTimeForDate(sessions, date, windows)
+	if _, ok := totals["Bobbob"]; ok {
+		t.Fatalf("expected Bob to be excluded by MinDuration, got %+v", totals["Bobbob"])
+	}
+}
+
+func TestCalculateTotalTimeForDateWeekdayMask(t *testing.T) {
+	// 2026-07-27 is a Monday; restrict the window to Tuesdays only so it
+	// never applies on this date.
+	date := time.Date(2026, 7, 27, 0, 0, 0, 0, time.UTC)
+	windows := []Window{
+		{Label: "tuesdays", Start: 9 * time.Hour, End: 10 * time.Hour, Timezone: time.UTC, Weekdays: []time.Weekday{time.Tuesday}},
+	}
+	sessions := []Session{
+		{
+			Name: "Cid", GoogleID: "cid",
+			StartTime: date.Add(9 * time.Hour),
+			EndTime:   date.Add(9*time.Hour + 30*time.Minute),
+		},
+	}
+
+	totals := CalculateTotalTimeForDate(sessions, date, windows)
+	if _, ok := totals["Cidcid"]; ok {
+		t.Fatalf("expected Cid to be excluded by the weekday mask, got %+v", totals["Cidcid"])
+	}
+}
@@ -0,0 +1,118 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// JSONLStore snapshots computed Sessions into one append-only JSONL file
+// per day, so repeated queries over a date that's already been scanned
+// don't need to hit Postgres again.
+type JSONLStore struct {
+	dir string
+}
+
+// NewJSONLStore returns a JSONLStore that keeps its per-day files under
+// dir, creating it if necessary.
+func NewJSONLStore(dir string) (*JSONLStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &JSONLStore{dir: dir}, nil
+}
+
+func (s *JSONLStore) pathForDate(date time.Time) string {
+	return filepath.Join(s.dir, date.UTC().Format("2006-01-02")+".jsonl")
+}
+
+// Append writes session as one JSON line in the file for its start date.
+func (s *JSONLStore) Append(session Session) error {
+	f, err := os.OpenFile(s.pathForDate(session.StartTime), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Between reads the per-day files covering [start, end) and returns the
+// sessions matching filter, oldest first, paginated by limit/before.
+func (s *JSONLStore) Between(start, end time.Time, filter Filter, limit int, before time.Time) ([]Session, error) {
+	var sessions []Session
+
+	for day := start.UTC().Truncate(24 * time.Hour); day.Before(end); day = day.Add(24 * time.Hour) {
+		daySessions, err := s.readDay(day)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		var inRange []Session
+		for _, sess := range daySessions {
+			if sess.StartTime.Before(start) || !sess.StartTime.Before(end) {
+				continue
+			}
+			if !before.IsZero() && !sess.StartTime.Before(before) {
+				continue
+			}
+			inRange = append(inRange, sess)
+		}
+		sessions = append(sessions, applyFilter(inRange, filter)...)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[len(sessions)-limit:]
+	}
+
+	return sessions, nil
+}
+
+// Latest returns the n most recent sessions for user, looking back 90 days.
+func (s *JSONLStore) Latest(user string, n int) ([]Session, error) {
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -90)
+	sessions, err := s.Between(start, end, Filter{User: user}, n, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	reversed := make([]Session, len(sessions))
+	for i, sess := range sessions {
+		reversed[len(sessions)-1-i] = sess
+	}
+	return reversed, nil
+}
+
+func (s *JSONLStore) readDay(day time.Time) ([]Session, error) {
+	f, err := os.Open(s.pathForDate(day))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sessions []Session
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sess Session
+		if err := json.Unmarshal(scanner.Bytes(), &sess); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, scanner.Err()
+}
@@ -0,0 +1,297 @@
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// voiceChannelUser mirrors a row of the raw voice_channel_user activity
+// table: one row per join/leave/heartbeat event.
+type voiceChannelUser struct {
+	ID          int64
+	UserID      string
+	ClanID      int64
+	ChannelID   int64
+	DisplayName string
+	CreateTime  string
+	UpdateTime  string
+	Active      int16
+}
+
+// PostgresStore reads opentalk sessions by rescanning the raw
+// voice_channel_user activity table. It has no write-behind cache, so
+// Append is a no-op; pair it with a JSONLStore when repeated re-queries of
+// the same range need to avoid rescanning raw rows.
+type PostgresStore struct {
+	db        *sql.DB
+	tableName string
+
+	stmtAll    *sql.Stmt
+	stmtByClan *sql.Stmt
+}
+
+// NewPostgresStore wraps an existing *sql.DB, preparing the statements used
+// by every query up front so the driver only plans them once. The caller
+// owns the connection's lifecycle.
+func NewPostgresStore(db *sql.DB, tableName string) (*PostgresStore, error) {
+	stmtAll, err := db.Prepare(fmt.Sprintf(
+		"SELECT id, user_id, clan_id, channel_id, display_name, create_time, update_time, active FROM %s WHERE create_time >= $1 AND create_time < $2", tableName))
+	if err != nil {
+		return nil, err
+	}
+
+	stmtByClan, err := db.Prepare(fmt.Sprintf(
+		"SELECT id, user_id, clan_id, channel_id, display_name, create_time, update_time, active FROM %s WHERE create_time >= $1 AND create_time < $2 AND clan_id = $3", tableName))
+	if err != nil {
+		stmtAll.Close()
+		return nil, err
+	}
+
+	return &PostgresStore{db: db, tableName: tableName, stmtAll: stmtAll, stmtByClan: stmtByClan}, nil
+}
+
+// Close releases the store's prepared statements. It does not close the
+// underlying *sql.DB, which the caller owns.
+func (s *PostgresStore) Close() error {
+	if err := s.stmtAll.Close(); err != nil {
+		return err
+	}
+	return s.stmtByClan.Close()
+}
+
+// Append is a no-op: PostgresStore always derives sessions from the raw
+// activity table, so there is nothing to persist.
+func (s *PostgresStore) Append(Session) error {
+	return nil
+}
+
+// Between rescans the raw activity table for [start, end), derives
+// sessions, and applies filter/limit/before.
+func (s *PostgresStore) Between(start, end time.Time, filter Filter, limit int, before time.Time) ([]Session, error) {
+	activities, err := s.fetchActivities(start, end, filter.ClanID)
+	if err != nil {
+		return nil, err
+	}
+
+	sortActivities(activities)
+	sessions := processActivities(activities)
+	sessions = filterSessions(sessions)
+	sessions = applyFilter(sessions, filter)
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	if !before.IsZero() {
+		trimmed := sessions[:0]
+		for _, s := range sessions {
+			if s.StartTime.Before(before) {
+				trimmed = append(trimmed, s)
+			}
+		}
+		sessions = trimmed
+	}
+
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[len(sessions)-limit:]
+	}
+
+	return sessions, nil
+}
+
+// Latest returns the n most recent sessions for user, looking back 90 days.
+func (s *PostgresStore) Latest(user string, n int) ([]Session, error) {
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -90)
+	sessions, err := s.Between(start, end, Filter{User: user}, n, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Between returns oldest-first; Latest is newest-first.
+	reversed := make([]Session, len(sessions))
+	for i, sess := range sessions {
+		reversed[len(sessions)-1-i] = sess
+	}
+	return reversed, nil
+}
+
+func applyFilter(sessions []Session, filter Filter) []Session {
+	if filter.User == "" && filter.ClanID == "" && filter.ChannelID == "" {
+		return sessions
+	}
+	var out []Session
+	for _, s := range sessions {
+		if filter.User != "" && s.GoogleID != filter.User {
+			continue
+		}
+		if filter.ClanID != "" && fmt.Sprintf("%d", s.ClanID) != filter.ClanID {
+			continue
+		}
+		if filter.ChannelID != "" && fmt.Sprintf("%d", s.ChannelID) != filter.ChannelID {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// fetchActivities reads raw voice_channel_user rows between start and end,
+// optionally narrowed to a single clan, using the store's prepared
+// statements.
+func (s *PostgresStore) fetchActivities(start, end time.Time, clanID string) ([]voiceChannelUser, error) {
+	var rows *sql.Rows
+	var err error
+	if clanID == "" {
+		rows, err = s.stmtAll.Query(start, end)
+	} else {
+		rows, err = s.stmtByClan.Query(start, end, clanID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []voiceChannelUser
+	for rows.Next() {
+		var ua voiceChannelUser
+		if err := rows.Scan(&ua.ID, &ua.UserID, &ua.ClanID, &ua.ChannelID, &ua.DisplayName, &ua.CreateTime, &ua.UpdateTime, &ua.Active); err != nil {
+			return nil, err
+		}
+		activities = append(activities, ua)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+// sortActivities orders rows by display name, then creation time.
+func sortActivities(activities []voiceChannelUser) {
+	const timeLayout = time.RFC3339
+
+	sort.Slice(activities, func(i, j int) bool {
+		timeI, errI := time.Parse(timeLayout, activities[i].CreateTime)
+		if errI != nil {
+			timeI = time.Time{}
+		}
+		timeJ, errJ := time.Parse(timeLayout, activities[j].CreateTime)
+		if errJ != nil {
+			timeJ = time.Time{}
+		}
+
+		if activities[i].DisplayName == activities[j].DisplayName {
+			return timeI.Before(timeJ)
+		}
+		return activities[i].DisplayName < activities[j].DisplayName
+	})
+}
+
+// processActivities turns join/leave activity rows into Sessions.
+func processActivities(activities []voiceChannelUser) []Session {
+	userSessions := make(map[string][]voiceChannelUser)
+	for _, activity := range activities {
+		userSessions[activity.DisplayName] = append(userSessions[activity.DisplayName], activity)
+	}
+
+	var sessions []Session
+	const timeLayout = time.RFC3339
+	for _, userActivities := range userSessions {
+		var currentSession *Session
+		for _, activity := range userActivities {
+			if activity.Active == 2 {
+				if currentSession == nil {
+					startTime, err := time.Parse(timeLayout, activity.CreateTime)
+					if err != nil {
+						return nil
+					}
+					endTime, err := time.Parse(timeLayout, activity.UpdateTime)
+					if err != nil {
+						return nil
+					}
+					currentSession = &Session{
+						Name:      activity.DisplayName,
+						GoogleID:  activity.UserID,
+						ClanID:    activity.ClanID,
+						ChannelID: activity.ChannelID,
+						StartTime: startTime,
+						EndTime:   endTime,
+					}
+				} else {
+					startTime, err := time.Parse(timeLayout, activity.CreateTime)
+					if err != nil {
+						return nil
+					}
+					endTime, err := time.Parse(timeLayout, activity.UpdateTime)
+					if err != nil {
+						return nil
+					}
+					currentSession.StartTime = minTime(currentSession.StartTime, startTime)
+					currentSession.EndTime = maxTime(currentSession.EndTime, endTime)
+				}
+			} else if activity.Active == 0 && currentSession != nil {
+				endTime, err := time.Parse(timeLayout, activity.UpdateTime)
+				if err != nil {
+					return nil
+				}
+				currentSession.EndTime = maxTime(currentSession.EndTime, endTime)
+				sessions = append(sessions, *currentSession)
+				currentSession = nil
+			}
+		}
+		if currentSession != nil {
+			sessions = append(sessions, *currentSession)
+		}
+	}
+
+	return sessions
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// filterSessions drops sessions that reside entirely within another session
+// for the same user.
+func filterSessions(sessions []Session) []Session {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Name == sessions[j].Name {
+			return sessions[i].StartTime.Before(sessions[j].StartTime)
+		}
+		return sessions[i].Name < sessions[j].Name
+	})
+
+	var filtered []Session
+	for i := 0; i < len(sessions); i++ {
+		current := sessions[i]
+		isSubSession := false
+
+		for j := 0; j < i; j++ {
+			if sessions[j].Name == current.Name &&
+				sessions[j].StartTime.Before(current.StartTime) &&
+				sessions[j].EndTime.After(current.EndTime) {
+				isSubSession = true
+				break
+			}
+		}
+
+		if !isSubSession {
+			filtered = append(filtered, current)
+		}
+	}
+
+	return filtered
+}
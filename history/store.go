@@ -0,0 +1,30 @@
+package history
+
+import "time"
+
+// Filter narrows a Between query to a subset of sessions. Zero values mean
+// "no restriction" for that field.
+type Filter struct {
+	User      string
+	ClanID    string
+	ChannelID string
+}
+
+// Store is a pluggable backend for recording and querying opentalk sessions,
+// modeled on the IRCv3 CHATHISTORY BEFORE/AFTER/BETWEEN/LATEST commands so
+// clients can page through arbitrary date ranges instead of one day at a
+// time.
+type Store interface {
+	// Append records a computed session so future queries don't need to
+	// rescan the raw activity rows it was derived from.
+	Append(session Session) error
+
+	// Between returns sessions starting in [start, end), oldest first,
+	// optionally narrowed by filter and paginated with limit/before.
+	// before is a cursor: when non-zero, only sessions starting strictly
+	// before it are returned.
+	Between(start, end time.Time, filter Filter, limit int, before time.Time) ([]Session, error)
+
+	// Latest returns the n most recent sessions for user, newest first.
+	Latest(user string, n int) ([]Session, error)
+}
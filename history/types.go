@@ -0,0 +1,111 @@
+// Package history provides persistent storage and windowed querying of
+// opentalk sessions, independent of how they were originally computed.
+package history
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// Session is a single continuous voice-channel presence for a user.
+type Session struct {
+	Name      string    `json:"fullName"`
+	GoogleID  string    `json:"google_id"`
+	ClanID    int64     `json:"clanId"`
+	ChannelID int64     `json:"channelId"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// WindowTotal is one user's opentalk time within a single scored Window on
+// a given date.
+type WindowTotal struct {
+	Label     string        `json:"label"`
+	TotalTime time.Duration `json:"totalTime"`
+}
+
+// SessionTime is the computed total opentalk time for a user on a given
+// date. TotalTime is the grand total across every scored Window; Windows
+// holds the same total broken down per window, e.g. a morning and
+// afternoon standup slot scored separately.
+type SessionTime struct {
+	Name      string        `json:"fullName"`
+	GoogleID  string        `json:"googleId"`
+	TotalTime time.Duration `json:"totalTime"`
+	Date      time.Time     `json:"date"`
+	Windows   []WindowTotal `json:"windows,omitempty"`
+}
+
+// MarshalJSON renders TotalTime (and each window's total) as whole minutes
+// and Date as yyyy-mm-dd, matching the shape the dashboard API has always
+// returned.
+func (s SessionTime) MarshalJSON() ([]byte, error) {
+	type windowOut struct {
+		Label     string `json:"label"`
+		TotalTime int    `json:"totalTime"`
+	}
+
+	var windows []windowOut
+	for _, w := range s.Windows {
+		windows = append(windows, windowOut{
+			Label:     w.Label,
+			TotalTime: int(math.Round(w.TotalTime.Minutes())),
+		})
+	}
+
+	return json.Marshal(&struct {
+		Name      string      `json:"fullName"`
+		GoogleID  string      `json:"googleId"`
+		TotalTime int         `json:"totalTime"`
+		Date      string      `json:"date"`
+		Windows   []windowOut `json:"windows,omitempty"`
+	}{
+		Name:      s.Name,
+		GoogleID:  s.GoogleID,
+		TotalTime: int(math.Round(s.TotalTime.Minutes())),
+		Date:      s.Date.Format("2006-01-02"),
+		Windows:   windows,
+	})
+}
+
+// UnmarshalJSON parses the wire shape written by MarshalJSON: TotalTime (and
+// each window's total) as whole minutes and Date as yyyy-mm-dd. Without this,
+// decoding falls back to time.Duration's nanosecond-based default and
+// time.Time's RFC3339 default, silently corrupting both fields.
+func (s *SessionTime) UnmarshalJSON(data []byte) error {
+	type windowIn struct {
+		Label     string `json:"label"`
+		TotalTime int    `json:"totalTime"`
+	}
+	var in struct {
+		Name      string     `json:"fullName"`
+		GoogleID  string     `json:"googleId"`
+		TotalTime int        `json:"totalTime"`
+		Date      string     `json:"date"`
+		Windows   []windowIn `json:"windows,omitempty"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	date, err := time.Parse("2006-01-02", in.Date)
+	if err != nil {
+		return err
+	}
+
+	var windows []WindowTotal
+	for _, w := range in.Windows {
+		windows = append(windows, WindowTotal{
+			Label:     w.Label,
+			TotalTime: time.Duration(w.TotalTime) * time.Minute,
+		})
+	}
+
+	s.Name = in.Name
+	s.GoogleID = in.GoogleID
+	s.TotalTime = time.Duration(in.TotalTime) * time.Minute
+	s.Date = date
+	s.Windows = windows
+	return nil
+}
@@ -0,0 +1,52 @@
+package history
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestSessionTimeJSONRoundTrip guards against MarshalJSON and UnmarshalJSON
+// drifting out of sync: a cache that writes with one and reads with the
+// other would otherwise fail silently (wrong Date) or loudly (TotalTime off
+// by the ratio between a minute and a nanosecond).
+func TestSessionTimeJSONRoundTrip(t *testing.T) {
+	want := SessionTime{
+		Name:      "Ada Lovelace",
+		GoogleID:  "ada-123",
+		TotalTime: 90 * time.Minute,
+		Date:      time.Date(2026, 7, 27, 0, 0, 0, 0, time.UTC),
+		Windows: []WindowTotal{
+			{Label: "morning", TotalTime: 30 * time.Minute},
+			{Label: "afternoon", TotalTime: 60 * time.Minute},
+		},
+	}
+
+	raw, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got SessionTime
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if got.Name != want.Name || got.GoogleID != want.GoogleID {
+		t.Fatalf("identity fields mismatch: got %+v, want %+v", got, want)
+	}
+	if got.TotalTime != want.TotalTime {
+		t.Fatalf("TotalTime = %v, want %v", got.TotalTime, want.TotalTime)
+	}
+	if !got.Date.Equal(want.Date) {
+		t.Fatalf("Date = %v, want %v", got.Date, want.Date)
+	}
+	if len(got.Windows) != len(want.Windows) {
+		t.Fatalf("Windows = %+v, want %+v", got.Windows, want.Windows)
+	}
+	for i := range want.Windows {
+		if got.Windows[i] != want.Windows[i] {
+			t.Fatalf("Windows[%d] = %+v, want %+v", i, got.Windows[i], want.Windows[i])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window describes one scored opentalk slot: the half-open interval
+// [Start, End) as an offset from local midnight in Timezone, optionally
+// restricted to a subset of weekdays. A session must overlap the window by
+// at least MinDuration to count towards that window's total.
+type Window struct {
+	Label       string
+	Start       time.Duration
+	End         time.Duration
+	Timezone    *time.Location
+	MinDuration time.Duration
+	Weekdays    []time.Weekday // nil or empty means every day
+}
+
+// DefaultWindows is the original hard-coded 03:00-05:00 UTC slot, kept as
+// the default so existing deployments see no behavior change.
+func DefaultWindows() []Window {
+	return []Window{
+		{
+			Label:    "opentalk",
+			Start:    3 * time.Hour,
+			End:      5 * time.Hour,
+			Timezone: time.UTC,
+		},
+	}
+}
+
+// Absolute returns the window's [start, end) as instants, expressed in the
+// window's timezone, for the calendar date that `date` falls on in that
+// timezone.
+func (w Window) Absolute(date time.Time) (time.Time, time.Time) {
+	tz := w.Timezone
+	if tz == nil {
+		tz = time.UTC
+	}
+	local := date.In(tz)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tz)
+	return midnight.Add(w.Start), midnight.Add(w.End)
+}
+
+func (w Window) appliesToWeekday(weekday time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWindow parses a single "HH:MM-HH:MM@Zone" spec, e.g.
+// "10:00-12:00@Asia/Ho_Chi_Minh". The "@Zone" suffix is optional and
+// defaults to UTC.
+func ParseWindow(spec string) (Window, error) {
+	tz := time.UTC
+	timeRange := spec
+	if idx := strings.Index(spec, "@"); idx != -1 {
+		timeRange = spec[:idx]
+		zoneName := spec[idx+1:]
+		loc, err := time.LoadLocation(zoneName)
+		if err != nil {
+			return Window{}, fmt.Errorf("invalid timezone %q: %w", zoneName, err)
+		}
+		tz = loc
+	}
+
+	parts := strings.SplitN(timeRange, "-", 2)
+	if len(parts) != 2 {
+		return Window{}, fmt.Errorf("invalid window %q: want HH:MM-HH:MM", spec)
+	}
+
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window start %q: %w", parts[0], err)
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window end %q: %w", parts[1], err)
+	}
+
+	return Window{Label: spec, Start: start, End: end, Timezone: tz}, nil
+}
+
+// ParseWindows parses a comma-separated list of window specs, as found in
+// the OPENTALK_WINDOWS config value.
+func ParseWindows(spec string) ([]Window, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultWindows(), nil
+	}
+
+	var windows []Window
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := ParseWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("want HH:MM")
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
@@ -0,0 +1,94 @@
+// Package ical renders opentalk sessions as an RFC 5545 VCALENDAR feed, so
+// users can subscribe from Google Calendar / Thunderbird / Apple Calendar
+// and see who was on opentalk when, without polling the JSON API.
+package ical
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thanhlong1203/timesheet_opentalk/history"
+)
+
+const dateTimeLayout = "20060102T150405Z"
+
+// Render builds a VCALENDAR stream containing one VEVENT per session plus
+// one VEVENT per scored window in each summaries entry, positioned at that
+// window's actual start/end (from windows) rather than a fixed slot, so a
+// non-default OPENTALK_WINDOWS configuration still produces a calendar event
+// at the right time.
+func Render(sessions []history.Session, summaries []history.SessionTime, windows []history.Window) string {
+	var b strings.Builder
+
+	windowByLabel := make(map[string]history.Window, len(windows))
+	for _, w := range windows {
+		windowByLabel[w.Label] = w
+	}
+
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//timesheet_opentalk//opentalk sessions//EN\r\n")
+	b.WriteString("CALSCALE:GREGORIAN\r\n")
+
+	for _, s := range sessions {
+		writeSessionEvent(&b, s)
+	}
+	for _, s := range summaries {
+		writeSummaryEvents(&b, s, windowByLabel)
+	}
+
+	b.WriteString("END:VCALENDAR\r\n")
+	return b.String()
+}
+
+func writeSessionEvent(b *strings.Builder, s history.Session) {
+	uid := s.GoogleID + "-" + strconv.FormatInt(s.StartTime.Unix(), 10)
+
+	b.WriteString("BEGIN:VEVENT\r\n")
+	fmt.Fprintf(b, "UID:%s\r\n", escape(uid))
+	fmt.Fprintf(b, "DTSTAMP:%s\r\n", time.Now().UTC().Format(dateTimeLayout))
+	fmt.Fprintf(b, "DTSTART:%s\r\n", s.StartTime.UTC().Format(dateTimeLayout))
+	fmt.Fprintf(b, "DTEND:%s\r\n", s.EndTime.UTC().Format(dateTimeLayout))
+	fmt.Fprintf(b, "SUMMARY:%s\r\n", escape(s.Name))
+	fmt.Fprintf(b, "X-OPENTALK-CLAN:%s\r\n", escape(strconv.FormatInt(s.ClanID, 10)))
+	b.WriteString("END:VEVENT\r\n")
+}
+
+// writeSummaryEvents writes one VEVENT per window total in s.Windows,
+// positioned at that window's actual absolute start/end for s.Date. A
+// window total whose label isn't in windowByLabel (e.g. stale cached data
+// from a since-changed OPENTALK_WINDOWS) is skipped rather than guessed at.
+func writeSummaryEvents(b *strings.Builder, s history.SessionTime, windowByLabel map[string]history.Window) {
+	for _, wt := range s.Windows {
+		w, ok := windowByLabel[wt.Label]
+		if !ok {
+			continue
+		}
+		windowStart, windowEnd := w.Absolute(s.Date)
+
+		uid := s.GoogleID + "-" + s.Date.Format("20060102") + "-" + wt.Label + "-summary"
+		totalMinutes := int(math.Round(wt.TotalTime.Minutes()))
+
+		b.WriteString("BEGIN:VEVENT\r\n")
+		fmt.Fprintf(b, "UID:%s\r\n", escape(uid))
+		fmt.Fprintf(b, "DTSTAMP:%s\r\n", time.Now().UTC().Format(dateTimeLayout))
+		fmt.Fprintf(b, "DTSTART:%s\r\n", windowStart.UTC().Format(dateTimeLayout))
+		fmt.Fprintf(b, "DTEND:%s\r\n", windowEnd.UTC().Format(dateTimeLayout))
+		fmt.Fprintf(b, "SUMMARY:%s\r\n", escape(fmt.Sprintf("%s %s opentalk total: %dm", s.Name, wt.Label, totalMinutes)))
+		b.WriteString("END:VEVENT\r\n")
+	}
+}
+
+// escape applies the RFC 5545 TEXT escaping rules to a single-line value.
+func escape(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\n", `\n`,
+	)
+	return r.Replace(s)
+}
@@ -8,58 +8,22 @@ import (
 	"math"
 	"net/http"
 	"os"
-	"sort"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
-)
-
-type VoiceChannelUser struct {
-	ID          int64  `json:"id"`
-	UserID      string `json:"user_id"`
-	ClanID      int64  `json:"clan_id"`
-	ChannelID   int64  `json:"channel_id"`
-	DisplayName string `json:"display_name"`
-	CreateTime  string `json:"create_time"`
-	UpdateTime  string `json:"update_time"`
-	Active      int16  `json:"active"`
-}
-
-type Session struct {
-	Name      string    `json:"fullName"`
-	GoogleID  string    `json:"google_id"`
-	StartTime time.Time `json:"startTime"`
-	EndTime   time.Time `json:"endTime"`
-}
 
-type SessionTime struct {
-	Name      string        `json:"fullName"`
-	GoogleID  string        `json:"googleId"`
-	TotalTime time.Duration `json:"totalTime"`
-	Date      time.Time     `json:"date"`
-}
+	"github.com/thanhlong1203/timesheet_opentalk/auth"
+	"github.com/thanhlong1203/timesheet_opentalk/cache"
+	"github.com/thanhlong1203/timesheet_opentalk/history"
+	"github.com/thanhlong1203/timesheet_opentalk/ical"
+	"github.com/thanhlong1203/timesheet_opentalk/stream"
+)
 
-// Custom JSON marshaling
-func (s SessionTime) MarshalJSON() ([]byte, error) {
-	type Alias SessionTime
-	totalMinutes := int(math.Round(s.TotalTime.Minutes()))
-	return json.Marshal(&struct {
-		Name      string `json:"fullName"`
-		GoogleID  string `json:"googleId"`
-		TotalTime int    `json:"totalTime"`
-		Date      string `json:"date"`
-		*Alias
-	}{
-		Name:     s.Name,
-		GoogleID: s.GoogleID,
-		// Convert `TotalTime` to string in the format "hh:mm:ss"
-		TotalTime: totalMinutes,
-		// Format `Date` as a string in the format "yyyy-mm-dd" (adjust as needed)
-		Date:  s.Date.Format("2006-01-02"),
-		Alias: (*Alias)(&s),
-	})
-}
+// streamTickInterval is how often /sessions/stream pushes a rolling-total
+// tick for each currently active user during the opentalk window.
+const streamTickInterval = 30 * time.Second
 
 func main() {
 
@@ -80,10 +44,112 @@ func main() {
 	apiPath := os.Getenv("API_PATH")
 	securityCode := os.Getenv("SECURITYCODE")
 
+	authModes := auth.ParseModes(os.Getenv("AUTH_MODE"))
+	hmacVerifier := auth.NewHMACVerifier(auth.ParseHMACSecrets(os.Getenv("HMAC_KEYS")))
+	oauthVerifier := auth.NewOAuthVerifier(os.Getenv("OAUTH_CLIENT_ID"))
+	authenticator := auth.NewAuthenticator(authModes, securityCode, hmacVerifier, oauthVerifier)
+
+	defaultWindows, err := history.ParseWindows(os.Getenv("OPENTALK_WINDOWS"))
+	if err != nil {
+		log.Fatalf("Invalid OPENTALK_WINDOWS: %v", err)
+	}
+
 	connStr := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=%s", user, password, dbname, host, dbPort, sslmode)
 
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(envInt("DB_MAX_OPEN_CONNS", 10))
+	db.SetConnMaxIdleTime(envDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute))
+
+	postgresStore, err := history.NewPostgresStore(db, tableName)
+	if err != nil {
+		log.Fatalf("Failed to prepare history store: %v", err)
+	}
+	defer postgresStore.Close()
+
+	// Wrap Postgres in a CachingStore when a snapshot directory is
+	// configured, so repeated queries over a day that's already closed out
+	// are served from JSONL files instead of rescanning raw activity rows.
+	var store history.Store = postgresStore
+	if snapshotDir := os.Getenv("HISTORY_SNAPSHOT_DIR"); snapshotDir != "" {
+		snapshotStore, err := history.NewJSONLStore(snapshotDir)
+		if err != nil {
+			log.Fatalf("Failed to prepare history snapshot store: %v", err)
+		}
+		store = history.NewCachingStore(postgresStore, snapshotStore)
+	}
+
+	var sessionTimeCache *cache.SessionTimeCache
+	if redisAddr := os.Getenv("REDIS_ADDR"); redisAddr != "" {
+		sessionTimeCache = cache.NewSessionTimeCache(redisAddr, envDuration("CACHE_TTL", 60*time.Second))
+	}
+
+	broadcaster := stream.NewBroadcaster()
+
+	totalMinutesToday := func(googleID string) (int, error) {
+		now := time.Now().UTC()
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		sessions, err := store.Between(startOfDay, startOfDay.Add(24*time.Hour), history.Filter{User: googleID}, 0, time.Time{})
+		if err != nil {
+			return 0, err
+		}
+		totals := history.CalculateTotalTimeForDate(sessions, now, defaultWindows)
+		for _, t := range totals {
+			if t.GoogleID == googleID {
+				return int(math.Round(t.TotalTime.Minutes())), nil
+			}
+		}
+		return 0, nil
+	}
+
+	// activeUsers approximates "currently joined" by looking at today's
+	// sessions whose end time (the row's last update, for a session that is
+	// still open) falls within the last tick interval.
+	activeUsers := func() ([]string, error) {
+		now := time.Now().UTC()
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		sessions, err := store.Between(startOfDay, now.Add(time.Second), history.Filter{}, 0, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		var users []string
+		for _, s := range sessions {
+			if now.Sub(s.EndTime) <= streamTickInterval {
+				users = append(users, s.GoogleID)
+			}
+		}
+		return users, nil
+	}
+
+	if listener, err := stream.NewPostgresListener(connStr, "opentalk_sessions", broadcaster, totalMinutesToday); err != nil {
+		log.Printf("stream: LISTEN/NOTIFY unavailable, live join/leave events disabled: %v", err)
+	} else {
+		defer listener.Close()
+	}
+
+	inWindow := func(now time.Time) bool {
+		for _, w := range defaultWindows {
+			start, end := w.Absolute(now)
+			if !now.Before(start) && now.Before(end) {
+				return true
+			}
+		}
+		return false
+	}
+	stopTicker := stream.StartTicker(broadcaster, streamTickInterval, inWindow, activeUsers, totalMinutesToday)
+	defer stopTicker()
+
 	// Create handler for API with totalTimeMap
 	http.HandleFunc(apiPath, func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authenticator.Authenticate(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
 		// Get time parameter from query string
 		timeParam := r.URL.Query().Get("time")
 		clanID := r.URL.Query().Get("clanID")
@@ -104,30 +170,63 @@ func main() {
 			}
 		}
 
-		// Fetch activities and process them
-		activities, err := FetchActivities(connStr, tableName, date, clanID)
+		windows, err := windowsForRequest(r, defaultWindows)
 		if err != nil {
-			log.Fatal(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		// Sort by name and creation time
-		SortActivities(activities)
+		// The cache key only covers (date, clanID) against the default
+		// windows for an unscoped caller, so a custom ?window= override or
+		// an OAuth-scoped request (which must only see its own sessions)
+		// always bypasses it.
+		cacheable := usingDefaultWindows(r) && identity.GoogleID == ""
+
+		if sessionTimeCache != nil && cacheable {
+			if cached, ok, err := sessionTimeCache.Get(r.Context(), date, clanID); err == nil && ok {
+				createHandleSessions(cached)(w, r)
+				return
+			} else if err != nil {
+				log.Printf("cache: get failed, falling back to Postgres: %v", err)
+			}
+		}
 
-		// Handle user sessions
-		sessions := processActivities(activities)
+		// Fetch the day's sessions from the history store
+		startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		endOfDay := startOfDay.Add(24 * time.Hour)
 
-		// Filters sessions that reside entirely within other sessions
-		filteredSessions := FilterSessions(sessions)
+		sessions, err := store.Between(startOfDay, endOfDay, history.Filter{ClanID: clanID, User: identity.GoogleID}, 0, time.Time{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		// Calculate the total time of each opentalk participant during the day
-		totalTime := CalculateTotalTimeForDate(filteredSessions, date)
+		totalTime := history.CalculateTotalTimeForDate(sessions, date, windows)
 
 		totalTimeMap := mapToSlice(totalTime)
 
+		if sessionTimeCache != nil && cacheable {
+			if err := sessionTimeCache.Set(r.Context(), date, clanID, totalTimeMap); err != nil {
+				log.Printf("cache: set failed: %v", err)
+			}
+		}
+
 		// Create handler for API with totalTimeMap
-		createHandleSessions(totalTimeMap, securityCode)(w, r)
+		createHandleSessions(totalTimeMap)(w, r)
 	})
 
+	// Windowed history endpoint: pages through arbitrary date ranges,
+	// CHATHISTORY-style, instead of one day at a time.
+	http.HandleFunc("/sessions/history", handleSessionsHistory(store, authenticator))
+
+	// iCalendar feed so users can subscribe from Google Calendar /
+	// Thunderbird / Apple Calendar and see who was on opentalk when.
+	http.HandleFunc("/sessions.ics", handleSessionsICS(store, authenticator, defaultWindows))
+
+	// Live presence feed: SSE stream of join/leave/tick events.
+	http.HandleFunc("/sessions/stream", handleSessionsStream(broadcaster, authenticator))
+
 	// Launch the server and report errors if any
 	serverPort1 := ":" + serverPort
 	log.Printf("Starting server on port %s...", serverPort1)
@@ -136,6 +235,35 @@ func main() {
 	}
 }
 
+// envInt reads an integer env var, falling back to def if unset or invalid.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %d", name, v, def)
+		return def
+	}
+	return n
+}
+
+// envDuration reads a time.Duration env var (e.g. "60s"), falling back to
+// def if unset or invalid.
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %s", name, v, def)
+		return def
+	}
+	return d
+}
+
 // Convert yyyy/mm/dd to time.Time
 func parseCustomDateFormat(dateStr string) (time.Time, error) {
 	// Parse yyyy/mm/dd format
@@ -149,252 +277,217 @@ func parseCustomDateFormat(dateStr string) (time.Time, error) {
 	return time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), 0, 0, 0, 0, time.UTC), nil
 }
 
-func mapToSlice(m map[string]SessionTime) []SessionTime {
-	var slice []SessionTime
+func mapToSlice(m map[string]history.SessionTime) []history.SessionTime {
+	var slice []history.SessionTime
 	for _, v := range m {
 		slice = append(slice, v)
 	}
 	return slice
 }
 
-// Get data from database
-func FetchActivities(connStr string, tableName string, date time.Time, clandID string) ([]VoiceChannelUser, error) {
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return nil, err
-	}
-	defer db.Close()
-
-	// Calculate the start and end of the day in UTC
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
-	endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Second)
-
-	startOfDayStr := startOfDay.Format(time.RFC3339)
-	endOfDayStr := endOfDay.Format(time.RFC3339)
+// usingDefaultWindows reports whether the request didn't override the
+// server's configured windows via ?window=.
+func usingDefaultWindows(r *http.Request) bool {
+	return r.URL.Query().Get("window") == ""
+}
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE create_time BETWEEN $1 AND $2", tableName)
+// windowsForRequest returns a single-window override from the "window"
+// (HH:MM-HH:MM) and "tz" query params if present, otherwise the server's
+// configured default windows.
+func windowsForRequest(r *http.Request, defaultWindows []history.Window) ([]history.Window, error) {
+	windowParam := r.URL.Query().Get("window")
+	if windowParam == "" {
+		return defaultWindows, nil
+	}
 
-	var rows *sql.Rows
-	if clandID == "" {
-		rows, err = db.Query(query, startOfDayStr, endOfDayStr)
-	} else {
-		query += " AND clan_id = $3"
-		rows, err = db.Query(query, startOfDayStr, endOfDayStr, clandID)
+	spec := windowParam
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		spec = windowParam + "@" + tz
 	}
 
+	w, err := history.ParseWindow(spec)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return []history.Window{w}, nil
+}
 
-	var activities []VoiceChannelUser
-	for rows.Next() {
-		var ua VoiceChannelUser
-		err := rows.Scan(&ua.ID, &ua.UserID, &ua.ClanID, &ua.ChannelID, &ua.DisplayName, &ua.CreateTime, &ua.UpdateTime, &ua.Active)
+// handleSessionsHistory serves paginated, filterable session history over
+// an arbitrary date range: start/end (RFC3339, required), and optional
+// user, clanID, channelID, limit and before (RFC3339 cursor for the next
+// page, IRCv3 CHATHISTORY-style).
+func handleSessionsHistory(store history.Store, authenticator *auth.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authenticator.Authenticate(r)
 		if err != nil {
-			return nil, err
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
 		}
-		activities = append(activities, ua)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return activities, nil
-}
 
-// Sort by name and creation time
-func SortActivities(activities []VoiceChannelUser) {
-	const timeLayout = time.RFC3339
+		q := r.URL.Query()
 
-	sort.Slice(activities, func(i, j int) bool {
-		timeI, errI := time.Parse(timeLayout, activities[i].CreateTime)
-		if errI != nil {
-			timeI = time.Time{}
+		start, err := time.Parse(time.RFC3339, q.Get("start"))
+		if err != nil {
+			http.Error(w, "invalid or missing start (RFC3339)", http.StatusBadRequest)
+			return
 		}
-		timeJ, errJ := time.Parse(timeLayout, activities[j].CreateTime)
-		if errJ != nil {
-			timeJ = time.Time{}
+		end, err := time.Parse(time.RFC3339, q.Get("end"))
+		if err != nil {
+			http.Error(w, "invalid or missing end (RFC3339)", http.StatusBadRequest)
+			return
 		}
 
-		if activities[i].DisplayName == activities[j].DisplayName {
-			return timeI.Before(timeJ)
+		limit := 100
+		if v := q.Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
 		}
-		return activities[i].DisplayName < activities[j].DisplayName
-	})
-}
 
-// Handle sessions and provide start and end times for each session
-func processActivities(activities []VoiceChannelUser) []Session {
-	userSessions := make(map[string][]VoiceChannelUser)
-	for _, activity := range activities {
-		userSessions[activity.DisplayName] = append(userSessions[activity.DisplayName], activity)
-	}
-
-	var sessions []Session
-	const timeLayout = time.RFC3339
-	for _, userActivities := range userSessions {
-		var currentSession *Session
-		for _, activity := range userActivities {
-			if activity.Active == 2 {
-				if currentSession == nil {
-					startTime, err := time.Parse(timeLayout, activity.CreateTime)
-					if err != nil {
-						return nil
-					}
-
-					endTime, err := time.Parse(timeLayout, activity.UpdateTime)
-					if err != nil {
-						return nil
-					}
-					currentSession = &Session{
-						Name:      activity.DisplayName,
-						GoogleID:  activity.UserID,
-						StartTime: startTime,
-						EndTime:   endTime,
-					}
-				} else {
-					startTime, err := time.Parse(timeLayout, activity.CreateTime)
-					if err != nil {
-						return nil
-					}
-
-					endTime, err := time.Parse(timeLayout, activity.UpdateTime)
-					if err != nil {
-						return nil
-					}
-					currentSession.StartTime = minTime(currentSession.StartTime, startTime)
-					currentSession.EndTime = maxTime(currentSession.EndTime, endTime)
-				}
-
-			} else if activity.Active == 0 && currentSession != nil {
-				endTime, err := time.Parse(timeLayout, activity.UpdateTime)
-				if err != nil {
-					return nil
-				}
-				currentSession.EndTime = maxTime(currentSession.EndTime, endTime)
-				sessions = append(sessions, *currentSession)
-				currentSession = nil
+		var before time.Time
+		if v := q.Get("before"); v != "" {
+			before, err = time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "invalid before (RFC3339)", http.StatusBadRequest)
+				return
 			}
 		}
-		if currentSession != nil {
-			sessions = append(sessions, *currentSession)
+
+		filter := history.Filter{
+			User:      q.Get("user"),
+			ClanID:    q.Get("clanID"),
+			ChannelID: q.Get("channelID"),
+		}
+		// An OAuth-authenticated caller can only ever see their own
+		// sessions, regardless of what ?user= they pass.
+		if identity.GoogleID != "" {
+			filter.User = identity.GoogleID
 		}
-	}
 
-	return sessions
-}
+		sessions, err := store.Between(start, end, filter, limit, before)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-// Get min time
-func minTime(a, b time.Time) time.Time {
-	if a.Before(b) {
-		return a
-	}
-	return b
-}
+		var nextBefore string
+		if limit > 0 && len(sessions) == limit {
+			nextBefore = sessions[0].StartTime.Format(time.RFC3339)
+		}
 
-// Get max time
-func maxTime(a, b time.Time) time.Time {
-	if a.After(b) {
-		return a
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Sessions   []history.Session `json:"sessions"`
+			NextBefore string            `json:"nextBefore,omitempty"`
+		}{
+			Sessions:   sessions,
+			NextBefore: nextBefore,
+		})
 	}
-	return b
 }
 
-// Handles sessions that reside entirely within another session
-func FilterSessions(sessions []Session) []Session {
-	// Sort sessions by Name and StartTime
-	sort.Slice(sessions, func(i, j int) bool {
-		if sessions[i].Name == sessions[j].Name {
-			return sessions[i].StartTime.Before(sessions[j].StartTime)
+// handleSessionsICS serves the day's sessions (and per-user daily totals)
+// as an RFC 5545 VCALENDAR feed, authenticated the same way as the JSON
+// API. It accepts the same "time" (yyyy/mm/dd) and "clanID" query params as
+// the daily JSON endpoint.
+func handleSessionsICS(store history.Store, authenticator *auth.Authenticator, defaultWindows []history.Window) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authenticator.Authenticate(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		windows, err := windowsForRequest(r, defaultWindows)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		return sessions[i].Name < sessions[j].Name
-	})
 
-	var filtered []Session
+		timeParam := r.URL.Query().Get("time")
+		clanID := r.URL.Query().Get("clanID")
 
-	for i := 0; i < len(sessions); i++ {
-		current := sessions[i]
-		isSubSession := false
+		now := time.Now()
+		date := now.UTC().AddDate(0, 0, -6)
 
-		// Checks if the current session is contained in any previous sessions with the same name
-		for j := 0; j < i; j++ {
-			if sessions[j].Name == current.Name &&
-				sessions[j].StartTime.Before(current.StartTime) &&
-				sessions[j].EndTime.After(current.EndTime) {
-				isSubSession = true
-				break
+		if timeParam != "" {
+			parsedTime, err := parseCustomDateFormat(timeParam)
+			if err != nil {
+				log.Printf("Invalid time parameter (custom format): %v, using default time", err)
+			} else {
+				date = parsedTime
 			}
 		}
 
-		// If it is not a secondary session, add it to the filtered list
-		if !isSubSession {
-			filtered = append(filtered, current)
+		startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		endOfDay := startOfDay.Add(24 * time.Hour)
+
+		sessions, err := store.Between(startOfDay, endOfDay, history.Filter{ClanID: clanID, User: identity.GoogleID}, 0, time.Time{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-	}
 
-	return filtered
+		summaries := mapToSlice(history.CalculateTotalTimeForDate(sessions, date, windows))
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		fmt.Fprint(w, ical.Render(sessions, summaries, windows))
+	}
 }
 
-// Calculate the total activity time of each user within a certain level in 1 day
-func CalculateTotalTimeForDate(sessions []Session, date time.Time) map[string]SessionTime {
-	totalTimeMap := make(map[string]SessionTime)
+// handleSessionsStream upgrades to Server-Sent Events and pushes a JSON
+// Event for every join, leave, and rolling-total tick until the client
+// disconnects.
+func handleSessionsStream(broadcaster *stream.Broadcaster, authenticator *auth.Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authenticator.Authenticate(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
 
-	// Determine the time interval from 3 to 5 UTC (10 to 12 UTC +7)
-	startOfDay := date.Truncate(24 * time.Hour)
-	start3h := startOfDay.Add(3 * time.Hour)
-	end5h := startOfDay.Add(5 * time.Hour)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
 
-	for _, s := range sessions {
-		// Check if the session is on the specified date
-		if s.StartTime.Year() == date.Year() && s.StartTime.YearDay() == date.YearDay() {
-			// Calculate the session validity period between 3 and 5
-			effectiveStart := s.StartTime
-			effectiveEnd := s.EndTime
+		events, unsubscribe := broadcaster.Subscribe()
+		defer unsubscribe()
 
-			if effectiveStart.Before(start3h) {
-				effectiveStart = start3h
-			}
-			if effectiveEnd.After(end5h) {
-				effectiveEnd = end5h
-			}
-			if effectiveStart.Before(effectiveEnd) {
-				duration := effectiveEnd.Sub(effectiveStart)
-				userKey := s.Name + s.GoogleID
-
-				// Update total time for users
-				if sessionTime, exists := totalTimeMap[userKey]; exists {
-					sessionTime.TotalTime += duration
-					totalTimeMap[userKey] = sessionTime
-				} else {
-					totalTimeMap[userKey] = SessionTime{
-						Name:      s.Name,
-						GoogleID:  s.GoogleID,
-						TotalTime: duration,
-						Date:      startOfDay,
-					}
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
 				}
+				if identity.GoogleID != "" && event.GoogleID != identity.GoogleID {
+					continue
+				}
+				payload, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "data: %s\n\n", payload)
+				flusher.Flush()
 			}
 		}
 	}
-
-	return totalTimeMap
 }
 
-// API handling with totalTime
-func createHandleSessions(sessionTimes []SessionTime, securityCode string) http.HandlerFunc {
+// createHandleSessions writes sessionTimes as JSON. Authentication is the
+// caller's responsibility.
+func createHandleSessions(sessionTimes []history.SessionTime) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		// Check Security-Code
-		if r.Header.Get("Security-Code") != securityCode {
-			http.Error(w, "Unauthorized Security-Code", http.StatusUnauthorized)
-			return
-		}
-
-		// Settings header to return JSON
 		w.Header().Set("Content-Type", "application/json")
-
 		json.NewEncoder(w).Encode(sessionTimes)
 	}
 }
@@ -0,0 +1,67 @@
+// Package stream turns voice_channel_user row changes into a live feed of
+// join/leave/tick events, so the tool can back a dashboard instead of only
+// serving daily batch reports.
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is pushed to every subscriber as JSON when a user joins, leaves, or
+// on a rolling-total tick during the configured opentalk window.
+type Event struct {
+	Type              string    `json:"type"` // "join", "leave", or "tick"
+	User              string    `json:"user"`
+	GoogleID          string    `json:"googleId"`
+	ClanID            int64     `json:"clanId"`
+	Ts                time.Time `json:"ts"`
+	TodayTotalMinutes int       `json:"todayTotalMinutes"`
+}
+
+// Broadcaster fans Events out to any number of subscribers. The zero value
+// is not usable; construct with NewBroadcaster.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewBroadcaster returns an empty Broadcaster ready to Publish/Subscribe.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe registers a new subscriber and returns its event channel plus
+// an unsubscribe func the caller must call when done (typically deferred).
+func (b *Broadcaster) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+		b.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends event to every current subscriber. A subscriber whose
+// buffer is full is skipped rather than blocking the publisher.
+func (b *Broadcaster) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package stream
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// notifyPayload mirrors the JSON pg_notify emits from the
+// notify_voice_channel_user_change trigger (see schema/notify_trigger.sql).
+type notifyPayload struct {
+	Active      int16  `json:"active"`
+	UserID      string `json:"user_id"`
+	ClanID      int64  `json:"clan_id"`
+	ChannelID   int64  `json:"channel_id"`
+	DisplayName string `json:"display_name"`
+	UpdateTime  string `json:"update_time"`
+}
+
+// TotalMinutesFunc looks up a user's running opentalk total for today, used
+// to populate Event.TodayTotalMinutes on join/leave.
+type TotalMinutesFunc func(googleID string) (int, error)
+
+// PostgresListener turns voice_channel_user NOTIFYs into join/leave Events
+// on a Broadcaster.
+type PostgresListener struct {
+	listener     *pq.Listener
+	channel      string
+	broadcaster  *Broadcaster
+	totalMinutes TotalMinutesFunc
+}
+
+// NewPostgresListener opens a LISTEN connection on channel and starts
+// translating NOTIFYs into Events published to broadcaster. Call Close
+// when done.
+func NewPostgresListener(connStr, channel string, broadcaster *Broadcaster, totalMinutes TotalMinutesFunc) (*PostgresListener, error) {
+	reportErr := func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("stream: listener error: %v", err)
+		}
+	}
+
+	listener := pq.NewListener(connStr, 10*time.Second, time.Minute, reportErr)
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	pl := &PostgresListener{
+		listener:     listener,
+		channel:      channel,
+		broadcaster:  broadcaster,
+		totalMinutes: totalMinutes,
+	}
+	go pl.run()
+	return pl, nil
+}
+
+// Close stops listening for NOTIFYs.
+func (p *PostgresListener) Close() error {
+	return p.listener.Close()
+}
+
+func (p *PostgresListener) run() {
+	for n := range p.listener.Notify {
+		if n == nil {
+			continue
+		}
+		p.handleNotification(n.Extra)
+	}
+}
+
+func (p *PostgresListener) handleNotification(payload string) {
+	var notif notifyPayload
+	if err := json.Unmarshal([]byte(payload), &notif); err != nil {
+		log.Printf("stream: invalid NOTIFY payload: %v", err)
+		return
+	}
+
+	eventType := "leave"
+	if notif.Active == 2 {
+		eventType = "join"
+	} else if notif.Active != 0 {
+		return
+	}
+
+	total := 0
+	if p.totalMinutes != nil {
+		if m, err := p.totalMinutes(notif.UserID); err == nil {
+			total = m
+		}
+	}
+
+	p.broadcaster.Publish(Event{
+		Type:              eventType,
+		User:              notif.DisplayName,
+		GoogleID:          notif.UserID,
+		ClanID:            notif.ClanID,
+		Ts:                time.Now().UTC(),
+		TodayTotalMinutes: total,
+	})
+}
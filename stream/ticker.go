@@ -0,0 +1,53 @@
+package stream
+
+import "time"
+
+// ActiveUsersFunc returns the google IDs currently joined to the voice
+// channel, used to decide who gets a rolling-total tick.
+type ActiveUsersFunc func() ([]string, error)
+
+// StartTicker publishes a "tick" Event for every currently active user
+// every interval, but only while inWindow returns true, so idle hours don't
+// produce pointless events. It returns a stop func.
+func StartTicker(broadcaster *Broadcaster, interval time.Duration, inWindow func(time.Time) bool, activeUsers ActiveUsersFunc, totalMinutes TotalMinutesFunc) func() {
+	stop := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if !inWindow(now) {
+					continue
+				}
+				tick(broadcaster, now, activeUsers, totalMinutes)
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
+
+func tick(broadcaster *Broadcaster, now time.Time, activeUsers ActiveUsersFunc, totalMinutes TotalMinutesFunc) {
+	users, err := activeUsers()
+	if err != nil {
+		return
+	}
+
+	for _, googleID := range users {
+		total := 0
+		if m, err := totalMinutes(googleID); err == nil {
+			total = m
+		}
+		broadcaster.Publish(Event{
+			Type:              "tick",
+			GoogleID:          googleID,
+			Ts:                now.UTC(),
+			TodayTotalMinutes: total,
+		})
+	}
+}